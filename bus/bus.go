@@ -0,0 +1,61 @@
+// Package bus implements a small publish/subscribe event bus, mirroring the
+// broker pattern common to CI and log-shipping pipelines: a single
+// producer publishes events and any number of independent consumers
+// ("sinks") subscribe to receive their own copy.
+package bus
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Event is a single decoded entry flowing through the bus, tagged with
+// enough context for a sink to report where it came from.
+type Event struct {
+	File  string          // absolute path of the file the entry came from
+	Index int             // position of the entry among those decoded since the reader was opened; not its position in the whole file
+	Entry json.RawMessage // the decoded entry itself
+}
+
+// Bus fans a stream of Events out to any number of subscribers. It is safe
+// for concurrent use.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[chan *Event]struct{}
+}
+
+// New creates an empty Bus.
+func New() *Bus {
+	return &Bus{subs: make(map[chan *Event]struct{})}
+}
+
+// Subscribe registers ch to receive every Event published after this call
+// returns. ch should be buffered; a subscriber that falls behind has
+// events dropped for it rather than blocking the publisher.
+func (b *Bus) Subscribe(ch chan *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[ch] = struct{}{}
+}
+
+// Unsubscribe removes ch so it no longer receives Events. It does not
+// close ch; the caller owns that.
+func (b *Bus) Unsubscribe(ch chan *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, ch)
+}
+
+// Publish delivers e to every current subscriber. Delivery is
+// non-blocking: a subscriber whose channel is full misses e rather than
+// stalling the other subscribers.
+func (b *Bus) Publish(e *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package filter evaluates jq expressions against decoded entries, backing
+// json-tail's --filter (keep only matching entries) and --select (project
+// a sub-value of each entry) flags. Expressions are parsed once at
+// startup so a typo fails fast instead of erroring on every tick.
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// Filter decides whether an entry should be emitted.
+type Filter struct {
+	query *gojq.Query
+}
+
+// Parse compiles a jq boolean expression, e.g. `.level == "error"`. An
+// empty expr is not an error; it simply means "no filter", and Match
+// always returns true.
+func Parse(expr string) (*Filter, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	q, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing filter %q: %w", expr, err)
+	}
+	return &Filter{query: q}, nil
+}
+
+// Match decodes raw and evaluates the filter against it, reporting
+// whether the entry passes. A query that produces no output or a falsy
+// first value (false or null) does not pass.
+func (f *Filter) Match(raw json.RawMessage) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+	var data any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return false, fmt.Errorf("decoding entry: %w", err)
+	}
+
+	iter := f.query.Run(data)
+	v, ok := iter.Next()
+	if !ok {
+		return false, nil
+	}
+	if err, ok := v.(error); ok {
+		return false, fmt.Errorf("evaluating filter: %w", err)
+	}
+	return truthy(v), nil
+}
+
+// Select projects a sub-value out of each entry, e.g. `.msg`.
+type Select struct {
+	query *gojq.Query
+}
+
+// ParseSelect compiles a jq expression used to project entries. An empty
+// expr is not an error; it simply means "no projection", and Project
+// returns its input unchanged.
+func ParseSelect(expr string) (*Select, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	q, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing select %q: %w", expr, err)
+	}
+	return &Select{query: q}, nil
+}
+
+// Project decodes raw, evaluates the select expression against it, and
+// re-encodes the first produced value. ok is false if the expression
+// produced no output, in which case the entry should be dropped.
+func (s *Select) Project(raw json.RawMessage) (result json.RawMessage, ok bool, err error) {
+	if s == nil {
+		return raw, true, nil
+	}
+	var data any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, false, fmt.Errorf("decoding entry: %w", err)
+	}
+
+	iter := s.query.Run(data)
+	v, iterOk := iter.Next()
+	if !iterOk {
+		return nil, false, nil
+	}
+	if err, isErr := v.(error); isErr {
+		return nil, false, fmt.Errorf("evaluating select: %w", err)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, false, fmt.Errorf("encoding projected entry: %w", err)
+	}
+	return out, true, nil
+}
+
+// truthy implements jq's definition of truthiness: everything except
+// false and null is true.
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
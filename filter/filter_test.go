@@ -0,0 +1,138 @@
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseEmptyIsNoFilter(t *testing.T) {
+	f, err := Parse("")
+	if err != nil {
+		t.Fatalf("Parse(\"\"): %v", err)
+	}
+	if f != nil {
+		t.Fatalf("Parse(\"\") = %v, want nil", f)
+	}
+}
+
+func TestParseRejectsInvalidExpression(t *testing.T) {
+	if _, err := Parse(".level =="); err == nil {
+		t.Fatal("Parse did not fail on a malformed expression")
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	f, err := Parse(`.level == "error"`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	cases := []struct {
+		entry string
+		want  bool
+	}{
+		{`{"level":"error","msg":"boom"}`, true},
+		{`{"level":"info","msg":"ignored"}`, false},
+	}
+	for _, c := range cases {
+		got, err := f.Match(json.RawMessage(c.entry))
+		if err != nil {
+			t.Fatalf("Match(%s): %v", c.entry, err)
+		}
+		if got != c.want {
+			t.Errorf("Match(%s) = %v, want %v", c.entry, got, c.want)
+		}
+	}
+}
+
+func TestFilterTruthySemantics(t *testing.T) {
+	// jq truthiness: only false and null are falsy, everything else
+	// (including 0 and "") is truthy.
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{".ok", true},
+		{".missing", false}, // missing key produces null
+		{".zero", true},
+		{".empty", true},
+		{".flag", false},
+	}
+	entry := json.RawMessage(`{"ok":1,"zero":0,"empty":"","flag":false}`)
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		got, err := f.Match(entry)
+		if err != nil {
+			t.Fatalf("Match with expr %q: %v", c.expr, err)
+		}
+		if got != c.want {
+			t.Errorf("Match with expr %q = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+func TestFilterMatchErrorOnBadJSON(t *testing.T) {
+	f, err := Parse(".level")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := f.Match(json.RawMessage(`not json`)); err == nil {
+		t.Fatal("Match did not fail on malformed entry JSON")
+	}
+}
+
+func TestSelectProject(t *testing.T) {
+	s, err := ParseSelect(".msg")
+	if err != nil {
+		t.Fatalf("ParseSelect: %v", err)
+	}
+
+	out, ok, err := s.Project(json.RawMessage(`{"level":"error","msg":"boom"}`))
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if !ok {
+		t.Fatal("Project reported ok=false for a present field")
+	}
+	if string(out) != `"boom"` {
+		t.Errorf("Project = %s, want %q", out, `"boom"`)
+	}
+}
+
+func TestSelectProjectMissingYieldsNull(t *testing.T) {
+	// gojq's .field.that.does.not.exist evaluates to null, which is a
+	// present (not absent) value, so Project should still report ok=true.
+	s, err := ParseSelect(".nope")
+	if err != nil {
+		t.Fatalf("ParseSelect: %v", err)
+	}
+	out, ok, err := s.Project(json.RawMessage(`{"level":"error"}`))
+	if err != nil {
+		t.Fatalf("Project: %v", err)
+	}
+	if !ok {
+		t.Fatal("Project reported ok=false for a query that produced null")
+	}
+	if string(out) != "null" {
+		t.Errorf("Project = %s, want null", out)
+	}
+}
+
+func TestParseSelectEmptyIsNoOp(t *testing.T) {
+	s, err := ParseSelect("")
+	if err != nil {
+		t.Fatalf("ParseSelect(\"\"): %v", err)
+	}
+	if s != nil {
+		t.Fatalf("ParseSelect(\"\") = %v, want nil", s)
+	}
+}
+
+func TestParseSelectRejectsInvalidExpression(t *testing.T) {
+	if _, err := ParseSelect(".msg["); err == nil {
+		t.Fatal("ParseSelect did not fail on a malformed expression")
+	}
+}
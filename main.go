@@ -1,29 +1,50 @@
 // Package main implements a JSON file monitor that watches for new entries
-// and displays them in real-time, similar to the 'tail' command but for JSON files.
-// This is a very basic implementation and only works with JSON files containing
-// an array of strings. It also expects that the array of strings is updated sequentially
-// with new entries at the end of the file. If the file is modified in other ways,
-// the behavior may be unpredictable.
+// and displays them in real-time, similar to the 'tail' command but for JSON
+// files. It supports both a top-level JSON array and newline-delimited JSON
+// (NDJSON), with arbitrary element shapes, and streams new entries from the
+// last known byte offset instead of re-parsing the whole file on every tick.
+// New entries are published to an internal event bus, so any number of
+// sinks (terminal, file, journald, ...) can consume them independently.
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/siliconchaos/json-tail/bus"
+	"github.com/siliconchaos/json-tail/filter"
+	"github.com/siliconchaos/json-tail/reader"
+	"github.com/siliconchaos/json-tail/server"
+	"github.com/siliconchaos/json-tail/sink"
 	"github.com/siliconchaos/json-tail/spinner"
+	"github.com/siliconchaos/json-tail/watch"
 )
 
 // Config holds the configuration for the application.
 // In Go, it's common to group related configuration fields in a struct.
 type Config struct {
-	filename string  // The JSON file path to monitor
-	interval float64 // The time interval (in seconds) between file checks
+	filename  string        // The JSON file path to monitor
+	interval  float64       // The polling interval in seconds (poll mode, or auto mode's polling fallback)
+	format    reader.Format // The structural format of the file (auto/string/ndjson/array)
+	template  string        // Optional text/template source used to render each entry
+	sinks     []string      // Sink specs, e.g. "stdout", "file:/path", "journald"
+	listen    string        // Address for the HTTP API, e.g. ":8080" (empty disables it)
+	ringSize  int           // Number of recent entries the HTTP API retains for late joiners
+	watchMode watch.Mode    // How to detect file changes (auto/fsnotify/poll)
+	filter    string        // jq expression; only matching entries are emitted
+	selectExp string        // jq expression projecting a sub-value of each entry
 }
 
 func main() {
@@ -46,86 +67,158 @@ func main() {
 		log.Fatal("Error getting absolute path: ", err)
 	}
 
+	tmpl, err := parseTemplate(config.template)
+	if err != nil {
+		log.Fatal("Error parsing template: ", err)
+	}
+	render := func(raw json.RawMessage) (string, error) {
+		return renderEntry(config.format, raw, tmpl)
+	}
+
+	flt, err := filter.Parse(config.filter)
+	if err != nil {
+		log.Fatal("Error parsing filter: ", err)
+	}
+	sel, err := filter.ParseSelect(config.selectExp)
+	if err != nil {
+		log.Fatal("Error parsing select: ", err)
+	}
+
 	// Set up graceful shutdown handling
 	// Buffer size of 1 ensures we don't miss the interrupt signal
 	// Mainly used to handle Ctrl+C (os.Interrupt) but can be extended
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
 
-	// Channel for communicating new entries between goroutines
-	// Unbuffered channel ensures synchronous communication
-	changes := make(chan []string)
-
 	// Create and start the spnr
 	spnr := spinner.New([]string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"})
-	// spinner.SetState("Waiting for changes...")
-	// spinner.Start()
 
-	// Display initial file contents
-	var initialLength int
-	if entries, err := readJSONFile(absPath); err == nil {
-		// Temporarily stop the spinner for initial output
-		// spinner.Stop()
+	// The event bus decouples the file monitor (the producer) from the
+	// sinks (the consumers) selected on the command line.
+	evBus := bus.New()
+	sinks, err := buildSinks(config.sinks, &sink.Terminal{Spinner: spnr, Render: render})
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	var sinksDone sync.WaitGroup
+	for _, s := range sinks {
+		sinksDone.Add(1)
+		go func(s sink.Sink) {
+			defer sinksDone.Done()
+			s.Run(ctx, evBus)
+		}(s)
+	}
+
+	// Optionally serve the bus over HTTP so remote clients can tail the
+	// file via SSE/WebSocket without shell access.
+	var httpServer *server.Server
+	if config.listen != "" {
+		httpServer = server.New(config.listen, evBus, config.ringSize)
+		sinksDone.Add(1)
+		go func() {
+			defer sinksDone.Done()
+			httpServer.Ingest(ctx, evBus)
+		}()
+		go func() {
+			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP server error: %v", err)
+			}
+		}()
+	}
+
+	// Open the reader at the start of the file and display its initial
+	// contents; the offset it ends up at becomes the starting point for
+	// monitoring.
+	rdr, err := reader.Open(absPath, config.format, 0)
+	if err != nil {
+		log.Fatal("Error opening file: ", err)
+	}
+
+	var startOffset int64
+	if entries, err := rdr.Read(); err == nil {
+		entries = applyFilter(entries, flt, sel)
 		fmt.Println("Initial entries (last 10)")
 		fmt.Println("----------------------------")
 		for _, entry := range lastN(entries, 10) {
-			fmt.Println(entry)
+			printEntry(config.format, entry, tmpl)
 		}
 		fmt.Println("----------------------------")
-		fmt.Printf("Monitoring file for new entries (checking every %.1f seconds)...\n\n", config.interval)
-		initialLength = len(entries)
+		fmt.Println("Monitoring file for new entries...")
+		fmt.Println()
+		startOffset = rdr.Offset()
 
 		// start the spinner
-		spnr.SetState("Waiting for changes...")
+		spnr.SetState("Watching")
 		spnr.Start()
 	}
 
 	// Start the file monitor in a separate goroutine
 	// This allows the main goroutine to handle user interrupts
-	go monitorFile(absPath, config.interval, changes, initialLength, spnr)
-
-	// Main event loop using select for concurrent channel operations
-	// Select blocks until one of its cases can proceed
-	for {
-		select {
-		case newEntries := <-changes:
-			// Temporarily stop the spinner to display new entries
-			spnr.Stop()
-			// Process and display new entries as they arrive
-			for _, entry := range newEntries {
-				fmt.Printf("[%s] %s\n", time.Now().Format(time.RFC3339), entry)
-			}
-			// Restart the spinner after displaying new entries
-			spnr.SetState("Waiting for changes...")
-			spnr.Start()
-		case <-sigChan:
-			// Handle graceful shutdown on interrupt (Ctrl+C)
-			spnr.Stop()
-			fmt.Println("\nReceived interrupt signal, exiting...")
-			return
+	pollInterval := time.Duration(config.interval * float64(time.Second))
+	go monitorFile(absPath, config.watchMode, pollInterval, config.format, startOffset, flt, sel, evBus, spnr)
+
+	// Block until the user asks us to stop, then let the sinks drain.
+	<-sigChan
+	spnr.Stop()
+	cancel()
+	if httpServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server shutdown error: %v", err)
 		}
 	}
+	sinksDone.Wait()
+	fmt.Println("\nReceived interrupt signal, exiting...")
 }
 
 // parseFlags processes command-line arguments and returns a Config struct.
 // It handles both short (-i) and long (--interval) flag formats.
 func parseFlags() Config {
 	var config Config
+	var formatFlag string
+	var watchFlag string
+	var sinkFlag sinkList
 
 	// Define command-line flags
 	// The flag package automatically generates help text (-h or --help)
-	const intervalHelp = "the interval in seconds at which to check the file for changes"
+	const intervalHelp = "the polling interval in seconds (used by --watch=poll, and by the default auto-detection when it falls back from fsnotify to polling)"
 	flag.Float64Var(&config.interval, "i", 1.0, intervalHelp)
 	flag.Float64Var(&config.interval, "interval", 1.0, intervalHelp)
 
+	const watchHelp = "how to detect file changes: fsnotify or poll (default: auto-detect, preferring fsnotify)"
+	flag.StringVar(&watchFlag, "watch", "", watchHelp)
+
+	const formatHelp = "the structural format of the file: string, ndjson, or array (default: auto-detect)"
+	flag.StringVar(&formatFlag, "format", "", formatHelp)
+
+	const templateHelp = "a Go text/template used to render each entry (default: print the raw JSON)"
+	flag.StringVar(&config.template, "template", "", templateHelp)
+
+	const filterHelp = `a jq expression; only entries for which it produces a truthy value are emitted, e.g. '.level == "error"'`
+	flag.StringVar(&config.filter, "filter", "", filterHelp)
+
+	const selectHelp = "a jq expression projecting a sub-value of each entry before it's emitted, e.g. '.msg'"
+	flag.StringVar(&config.selectExp, "select", "", selectHelp)
+
+	const sinkHelp = "where to send new entries; repeatable. One of stdout, file:<path>, journald (default: stdout)"
+	flag.Var(&sinkFlag, "sink", sinkHelp)
+
+	const listenHelp = "address to serve the HTTP API on (SSE/WebSocket/history), e.g. :8080 (default: disabled)"
+	flag.StringVar(&config.listen, "listen", "", listenHelp)
+
+	const ringSizeHelp = "number of recent entries the HTTP API retains for late-joining clients"
+	flag.IntVar(&config.ringSize, "ring-size", 1000, ringSizeHelp)
+
 	// Parse command-line flags
 	flag.Parse()
 
 	// Get non-flag arguments (expected to be the filename)
 	args := flag.Args()
 	if len(args) < 1 {
-		log.Fatal("Please provide a JSON file to monitor\nUsage: json-tail <filename> [-i <interval>]")
+		log.Fatal("Please provide a JSON file to monitor\nUsage: json-tail <filename> [-i <interval>] [--format string|ndjson|array] [--template tmpl] [--sink stdout|file:<path>|journald] [--filter expr] [--select expr]")
 	}
 
 	config.filename = args[0]
@@ -135,9 +228,82 @@ func parseFlags() Config {
 		log.Fatal("Interval must be a positive number")
 	}
 
+	format, err := reader.ParseFormat(formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.format = format
+
+	watchMode, err := watch.ParseMode(watchFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.watchMode = watchMode
+
+	if len(sinkFlag) == 0 {
+		sinkFlag = sinkList{"stdout"}
+	}
+	config.sinks = sinkFlag
+
 	return config
 }
 
+// sinkList collects repeated --sink flags into a slice, since flag.Value
+// is the package's hook for flags that may appear more than once.
+type sinkList []string
+
+func (s *sinkList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *sinkList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// buildSinks resolves a list of --sink specs into sink.Sink implementations.
+// "stdout" and "terminal" both select term; "file:<path>" selects a
+// rotating file sink writing to <path>; "journald" selects the systemd
+// journal sink.
+func buildSinks(specs []string, term *sink.Terminal) ([]sink.Sink, error) {
+	sinks := make([]sink.Sink, 0, len(specs))
+	termAdded := false
+	for _, spec := range specs {
+		name, arg, _ := strings.Cut(spec, ":")
+		switch name {
+		case "stdout", "terminal":
+			// "stdout" and "terminal" are aliases for the same sink, so
+			// repeating either (or both) must not spawn term.Run twice —
+			// that would print every entry twice and race two spinner
+			// goroutines against each other.
+			if termAdded {
+				continue
+			}
+			sinks = append(sinks, term)
+			termAdded = true
+		case "file":
+			if arg == "" {
+				return nil, fmt.Errorf("sink %q requires a path, e.g. file:/var/log/json-tail.out", spec)
+			}
+			sinks = append(sinks, &sink.File{Path: arg})
+		case "journald":
+			sinks = append(sinks, &sink.Journald{})
+		default:
+			return nil, fmt.Errorf("unknown sink %q (want stdout, file:<path>, or journald)", spec)
+		}
+	}
+	return sinks, nil
+}
+
+// parseTemplate compiles src as a text/template, returning nil if src is
+// empty so callers can fall back to a default rendering.
+func parseTemplate(src string) (*template.Template, error) {
+	if src == "" {
+		return nil, nil
+	}
+	return template.New("entry").Parse(src)
+}
+
 // validateFile checks if the specified file exists, is readable,
 // and is not a directory.
 func validateFile(filename string) error {
@@ -163,60 +329,173 @@ func validateFile(filename string) error {
 	return nil
 }
 
-// monitorFile watches the specified file for changes and sends new entries
-// through the changes channel. It runs continuously until the program exits.
-// The changes parameter is a send-only channel (chan<-) as this function
-// only sends data and never receives from the channel.
-func monitorFile(filename string, interval float64, changes chan<- []string, previousLength int, spinner *spinner.Spinner) {
-	// Create a ticker for regular interval checks
-	// time.Duration is a type representing nanosecond precision
-	ticker := time.NewTicker(time.Duration(interval * float64(time.Second)))
-	defer ticker.Stop() // Ensure ticker is stopped when function returns
-
-	// Loop indefinitely, checking for new entries on each tick
-	for range ticker.C {
-		spinner.SetState("Checking for changes...")
-		entries, err := readJSONFile(filename)
+// monitorFile watches the specified file for changes and publishes new
+// entries to b. It runs continuously until the watcher stops.
+func monitorFile(filename string, watchMode watch.Mode, pollInterval time.Duration, format reader.Format, offset int64, flt *filter.Filter, sel *filter.Select, b *bus.Bus, spnr *spinner.Spinner) {
+	rdr, err := reader.Open(filename, format, offset)
+	if err != nil {
+		log.Printf("Error opening file: %v", err)
+		spnr.SetState("Error")
+		return
+	}
+
+	w, err := watch.New(filename, watchMode, pollInterval)
+	if err != nil {
+		log.Printf("Error starting watcher: %v", err)
+		spnr.SetState("Error")
+		return
+	}
+	defer w.Close()
+
+	publish := func() {
+		entries, err := rdr.Read()
 		if err != nil {
 			log.Printf("Error reading file: %v", err)
-			spinner.SetState("Error reading file")
-			continue
+			spnr.SetState("Error")
+			return
 		}
+		// Filtering and projecting here, before publishing, means the
+		// cost is paid once no matter how many sinks are subscribed, and
+		// sinks never see entries the filter rejected.
+		for _, entry := range applyFilter(entries, flt, sel) {
+			b.Publish(&bus.Event{File: filename, Index: entry.Index, Entry: entry.Raw})
+		}
+		spnr.SetState("Watching")
+	}
+
+	// Pick up anything written between the initial read and the watcher
+	// starting.
+	publish()
 
-		// If new entries are found, send them through the channel
-		if len(entries) > previousLength {
-			newEntries := entries[previousLength:]
-			changes <- newEntries
-			previousLength = len(entries)
+	for {
+		select {
+		case ev, ok := <-w.Events():
+			if !ok {
+				return
+			}
+			switch ev {
+			case watch.EventWrite:
+				publish()
+			case watch.EventRotate, watch.EventCreate:
+				spnr.SetState("Rotated")
+				rdr, err = reader.Open(filename, format, 0)
+				if err != nil {
+					log.Printf("Error reopening file: %v", err)
+					spnr.SetState("Error")
+					continue
+				}
+				spnr.SetState("Reopened")
+				publish()
+			}
+		case err, ok := <-w.Errors():
+			if !ok {
+				continue
+			}
+			log.Printf("Watcher error: %v", err)
+			spnr.SetState("Error")
 		}
-		spinner.SetState("Waiting for changes...")
 	}
 }
 
+// applyFilter keeps only the entries flt matches (all of them if flt is
+// nil) and projects each through sel (unchanged if sel is nil). An entry
+// that fails evaluation is logged and dropped rather than propagated,
+// since a single malformed entry shouldn't take down the whole stream.
+func applyFilter(entries []reader.Entry, flt *filter.Filter, sel *filter.Select) []reader.Entry {
+	if flt == nil && sel == nil {
+		return entries
+	}
+
+	out := make([]reader.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if flt != nil {
+			matched, err := flt.Match(entry.Raw)
+			if err != nil {
+				log.Printf("Error evaluating filter for entry %d: %v", entry.Index, err)
+				continue
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		if sel != nil {
+			projected, ok, err := sel.Project(entry.Raw)
+			if err != nil {
+				log.Printf("Error evaluating select for entry %d: %v", entry.Index, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			entry.Raw = projected
+		}
+
+		out = append(out, entry)
+	}
+	return out
+}
+
 // lastN returns the last n elements of a slice.
 // If the slice has fewer than n elements, it returns the entire slice.
-func lastN(entries []string, n int) []string {
+func lastN(entries []reader.Entry, n int) []reader.Entry {
 	if len(entries) <= n {
 		return entries
 	}
 	return entries[len(entries)-n:]
 }
 
-// readJSONFile reads and parses a JSON file containing an array of strings.
-// It returns the parsed entries and any error encountered during reading
-// or parsing.
-func readJSONFile(filename string) ([]string, error) {
-	// Read entire file into memory
-	// TODO: For large files, you might want to use streaming JSON decoding instead
-	data, err := os.ReadFile(filename)
+// printEntry renders a single entry to stdout, using tmpl if one was
+// provided or a format-appropriate default otherwise.
+func printEntry(format reader.Format, entry reader.Entry, tmpl *template.Template) {
+	rendered, err := renderEntry(format, entry.Raw, tmpl)
 	if err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		log.Printf("Error rendering entry %d: %v", entry.Index, err)
+		return
+	}
+	fmt.Println(rendered)
+}
+
+// renderEntry decodes raw according to format and executes tmpl against it,
+// or falls back to a sensible default rendering when tmpl is nil: the bare
+// string for FormatString entries (preserving the original json-tail
+// output, which this also applies to in FormatAuto when an entry happens
+// to be a plain JSON string), or the raw JSON otherwise.
+func renderEntry(format reader.Format, raw json.RawMessage, tmpl *template.Template) (string, error) {
+	if format == reader.FormatString {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", fmt.Errorf("decoding string entry: %w", err)
+		}
+		if tmpl == nil {
+			return s, nil
+		}
+		return executeTemplate(tmpl, s)
 	}
 
-	var entries []string
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	if tmpl == nil {
+		if format == reader.FormatAuto {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				return s, nil
+			}
+		}
+		return string(raw), nil
+	}
+
+	var data any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", fmt.Errorf("decoding entry: %w", err)
 	}
+	return executeTemplate(tmpl, data)
+}
 
-	return entries, nil
+// executeTemplate renders tmpl against data and returns the result as a
+// string.
+func executeTemplate(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
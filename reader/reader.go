@@ -0,0 +1,258 @@
+// Package reader implements a streaming decoder for JSON sources, either a
+// single top-level JSON array or a newline-delimited JSON (NDJSON) stream.
+// Unlike a plain os.ReadFile + json.Unmarshal round trip, a Reader tracks
+// the byte offset of the last entry it decoded so a caller can resume
+// exactly where it left off instead of re-parsing the file from the start
+// on every read — the approach that makes tailing multi-GB files practical.
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format selects how a file's contents are structured.
+type Format string
+
+const (
+	// FormatAuto sniffs the structural format from the first non-whitespace
+	// byte of the file: '[' means a JSON array, anything else means NDJSON.
+	FormatAuto Format = ""
+	// FormatString preserves the original json-tail behavior: a top-level
+	// JSON array of strings.
+	FormatString Format = "string"
+	// FormatNDJSON is a stream of newline-delimited JSON values.
+	FormatNDJSON Format = "ndjson"
+	// FormatArray is a top-level JSON array of arbitrary JSON values.
+	FormatArray Format = "array"
+)
+
+// ParseFormat validates and normalizes a --format flag value.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatAuto, FormatString, FormatNDJSON, FormatArray:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want %q, %q or %q)", s, FormatString, FormatNDJSON, FormatArray)
+	}
+}
+
+// Entry is a single decoded value along with its position in the stream.
+type Entry struct {
+	Index int
+	Raw   json.RawMessage
+}
+
+// Reader incrementally decodes entries from filename, remembering the byte
+// offset of the next unread entry between calls to Read. A Reader does not
+// keep the file open between reads, so it tolerates the file being rotated
+// out from under it (the caller is expected to detect rotation and create a
+// fresh Reader at offset 0 when that happens).
+type Reader struct {
+	filename string
+	format   Format // resolved structural format; never FormatAuto once set
+	offset   int64  // byte offset of the next entry to decode
+	index    int    // index to assign to the next decoded entry
+}
+
+// Open creates a Reader for filename that will start decoding at offset. If
+// format is FormatAuto, the structural format is sniffed from the file's
+// contents; FormatString is treated the same as FormatArray structurally
+// and only affects how entries are rendered later.
+func Open(filename string, format Format, offset int64) (*Reader, error) {
+	structural := format
+	if structural == FormatAuto || structural == FormatString {
+		sniffed, err := sniffFormat(filename)
+		if err != nil {
+			return nil, err
+		}
+		if structural == FormatAuto {
+			structural = sniffed
+		}
+	}
+
+	return &Reader{
+		filename: filename,
+		format:   structural,
+		offset:   offset,
+	}, nil
+}
+
+// Format reports the resolved structural format (never FormatAuto).
+func (r *Reader) Format() Format {
+	if r.format == FormatString {
+		return FormatArray
+	}
+	return r.format
+}
+
+// Offset reports the byte offset of the next entry to decode.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Read decodes any entries that have been appended since the last call (or
+// since offset, for a fresh Reader) and returns them in order.
+func (r *Reader) Read() ([]Entry, error) {
+	f, err := os.Open(r.filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", r.filename, err)
+	}
+	defer f.Close()
+
+	if r.format == FormatNDJSON {
+		return r.readNDJSON(f)
+	}
+	return r.readArray(f)
+}
+
+// sniffFormat peeks at the first non-whitespace byte of filename to decide
+// whether it holds a JSON array or an NDJSON stream. An empty or
+// not-yet-existent file is treated as NDJSON, since that's the cheaper
+// format to resume once data does arrive.
+func sniffFormat(filename string) (Format, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FormatNDJSON, nil
+		}
+		return "", fmt.Errorf("opening %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return FormatNDJSON, nil
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '[':
+			return FormatArray, nil
+		default:
+			return FormatNDJSON, nil
+		}
+	}
+}
+
+// readNDJSON decodes complete lines starting at r.offset. A trailing line
+// with no newline yet (the writer is mid-append) is left unconsumed so it
+// is picked up whole on the next call.
+func (r *Reader) readNDJSON(f *os.File) ([]Entry, error) {
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seeking %s: %w", r.filename, err)
+	}
+
+	br := bufio.NewReader(f)
+	offset := r.offset
+	var entries []Entry
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return entries, fmt.Errorf("reading %s: %w", r.filename, err)
+		}
+		offset += int64(len(line))
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			raw := make(json.RawMessage, len(trimmed))
+			copy(raw, trimmed)
+			entries = append(entries, Entry{Index: r.index, Raw: raw})
+			r.index++
+		}
+	}
+	r.offset = offset
+	return entries, nil
+}
+
+// readArray decodes elements of a top-level JSON array starting at
+// r.offset. When r.offset is 0 it walks the real opening '[' with
+// encoding/json's token API; when resuming mid-array it skips the comma
+// left behind by the previous element and splices in a synthetic '[' so
+// the decoder sees a syntactically valid array, then translates its
+// InputOffset back into a real file offset.
+func (r *Reader) readArray(f *os.File) ([]Entry, error) {
+	if _, err := f.Seek(r.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seeking %s: %w", r.filename, err)
+	}
+
+	midArray := r.offset != 0
+
+	var src io.Reader = f
+	baseOffset := r.offset
+	if midArray {
+		pos, closed, err := skipArraySeparator(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", r.filename, err)
+		}
+		if closed {
+			return nil, nil
+		}
+		baseOffset = pos
+		src = io.MultiReader(bytes.NewReader([]byte{'['}), f)
+	}
+
+	dec := json.NewDecoder(src)
+	if _, err := dec.Token(); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", r.filename, err)
+	}
+
+	var entries []Entry
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		entries = append(entries, Entry{Index: r.index, Raw: raw})
+		r.index++
+	}
+
+	consumed := dec.InputOffset()
+	if midArray {
+		consumed-- // discount the synthetic '[' we spliced in
+	}
+	r.offset = baseOffset + consumed
+	return entries, nil
+}
+
+// skipArraySeparator advances f past the comma and whitespace separating
+// the previously-read element from the next one, leaving f positioned at
+// the first byte of the next element. It reports the real file offset at
+// that position, or closed=true if it instead finds the array's closing
+// ']' (no new element is available yet).
+func skipArraySeparator(f *os.File) (pos int64, closed bool, err error) {
+	for {
+		var b [1]byte
+		n, readErr := f.Read(b[:])
+		if n == 0 {
+			if readErr == io.EOF {
+				return 0, true, nil
+			}
+			return 0, false, readErr
+		}
+
+		switch b[0] {
+		case ',', ' ', '\t', '\n', '\r':
+			continue
+		case ']':
+			return 0, true, nil
+		default:
+			cur, err := f.Seek(-1, io.SeekCurrent)
+			if err != nil {
+				return 0, false, err
+			}
+			return cur, false, nil
+		}
+	}
+}
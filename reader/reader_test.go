@@ -0,0 +1,133 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReaderResumesNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ndjson")
+	writeFile(t, path, `{"a":1}`+"\n")
+
+	r, err := Open(path, FormatAuto, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if r.Format() != FormatNDJSON {
+		t.Fatalf("Format() = %q, want %q", r.Format(), FormatNDJSON)
+	}
+
+	entries := mustRead(t, r)
+	wantEntries(t, entries, `{"a":1}`)
+
+	appendFile(t, path, `{"a":2}`+"\n")
+	entries = mustRead(t, r)
+	wantEntries(t, entries, `{"a":2}`)
+
+	// Re-opening at the offset the first Reader left off at must not
+	// re-decode anything already seen.
+	r2, err := Open(path, FormatAuto, r.Offset())
+	if err != nil {
+		t.Fatalf("Open (resume): %v", err)
+	}
+	if entries := mustRead(t, r2); len(entries) != 0 {
+		t.Fatalf("resumed reader decoded %d unexpected entries: %+v", len(entries), entries)
+	}
+}
+
+func TestReaderResumesMidArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	writeFile(t, path, `[{"a":1},{"a":2}`)
+
+	r, err := Open(path, FormatAuto, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if r.Format() != FormatArray {
+		t.Fatalf("Format() = %q, want %q", r.Format(), FormatArray)
+	}
+
+	entries := mustRead(t, r)
+	wantEntries(t, entries, `{"a":1}`, `{"a":2}`)
+
+	// Nothing new yet: the array isn't closed and no comma follows the
+	// last element, so a second Read at the same offset must be a no-op.
+	if entries := mustRead(t, r); len(entries) != 0 {
+		t.Fatalf("re-read before append decoded %d unexpected entries: %+v", len(entries), entries)
+	}
+
+	// Append another element mid-array (the comma plus the new element,
+	// no closing bracket yet) and confirm the reader resumes correctly
+	// from its stored offset rather than re-parsing from the start.
+	appendFile(t, path, `,{"a":3}`)
+	entries = mustRead(t, r)
+	wantEntries(t, entries, `{"a":3}`)
+
+	appendFile(t, path, `]`)
+	if entries := mustRead(t, r); len(entries) != 0 {
+		t.Fatalf("closing the array produced %d unexpected entries: %+v", len(entries), entries)
+	}
+}
+
+func TestReaderSniffsFormat(t *testing.T) {
+	arrPath := filepath.Join(t.TempDir(), "arr.json")
+	writeFile(t, arrPath, "  [1,2]")
+	r, err := Open(arrPath, FormatAuto, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if r.Format() != FormatArray {
+		t.Fatalf("Format() = %q, want %q", r.Format(), FormatArray)
+	}
+
+	ndPath := filepath.Join(t.TempDir(), "nd.json")
+	writeFile(t, ndPath, `{"a":1}`+"\n")
+	r, err = Open(ndPath, FormatAuto, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if r.Format() != FormatNDJSON {
+		t.Fatalf("Format() = %q, want %q", r.Format(), FormatNDJSON)
+	}
+}
+
+func mustRead(t *testing.T, r *Reader) []Entry {
+	t.Helper()
+	entries, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return entries
+}
+
+func wantEntries(t *testing.T, got []Entry, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, e := range got {
+		if string(e.Raw) != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, e.Raw, want[i])
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("appending to %s: %v", path, err)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/siliconchaos/json-tail/bus"
+)
+
+// ring is a fixed-capacity, append-only (from the consumer's point of
+// view) history of recently published events, each tagged with a
+// monotonically increasing sequence number so clients can resume a stream
+// ("Last-Event-ID", a remembered seq) without re-fetching everything. It
+// also owns the live fan-out to connected clients: add and subscribe share
+// a single lock so a client's history snapshot and its live stream can
+// never both contain the same Frame.
+type ring struct {
+	mu      sync.Mutex
+	size    int
+	nextSeq int64
+	buf     []Frame
+	subs    map[chan Frame]struct{}
+}
+
+func newRing(size int) *ring {
+	return &ring{size: size, subs: make(map[chan Frame]struct{})}
+}
+
+// add records e, broadcasts it to every subscriber, and returns the Frame
+// it was stored as.
+func (r *ring) add(e *bus.Event) Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := Frame{
+		Seq:   r.nextSeq,
+		Ts:    time.Now(),
+		File:  e.File,
+		Index: e.Index,
+		Entry: e.Entry,
+	}
+	r.nextSeq++
+
+	r.buf = append(r.buf, f)
+	if len(r.buf) > r.size {
+		r.buf = r.buf[len(r.buf)-r.size:]
+	}
+
+	for ch := range r.subs {
+		select {
+		case ch <- f:
+		default:
+		}
+	}
+	return f
+}
+
+// subscribe registers a channel that receives every future Frame and
+// returns, atomically with that registration, every retained Frame with
+// Seq > lastSeq (or the whole retained buffer if lastSeq is -1). Taking
+// the snapshot and registering the subscription under the single lock add
+// also uses guarantees a Frame is delivered exactly once: it's either
+// already in the snapshot or arrives later on the channel, never both.
+func (r *ring) subscribe(lastSeq int64) (snapshot []Frame, ch chan Frame, unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.buf {
+		if f.Seq > lastSeq {
+			snapshot = append(snapshot, f)
+		}
+	}
+
+	ch = make(chan Frame, 256)
+	r.subs[ch] = struct{}{}
+	return snapshot, ch, func() {
+		r.mu.Lock()
+		delete(r.subs, ch)
+		r.mu.Unlock()
+	}
+}
+
+// page returns up to limit retained Frames whose Index >= from, in order.
+// Because the ring only retains the last size events, callers more than
+// size entries behind will see a gap rather than the true full history.
+func (r *ring) page(from, limit int) []Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Frame
+	for _, f := range r.buf {
+		if f.Index < from {
+			continue
+		}
+		out = append(out, f)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
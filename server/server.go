@@ -0,0 +1,225 @@
+// Package server exposes the event bus over HTTP so a remote process (or a
+// browser) can tail a JSON file without shell access: Server-Sent Events
+// and WebSocket streams for live updates, a small paged history endpoint
+// backed by a ring buffer, and a liveness check.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/siliconchaos/json-tail/bus"
+)
+
+// defaultRingSize is used when New is given a non-positive size.
+const defaultRingSize = 1000
+
+// Frame is the JSON shape broadcast to every client and returned by the
+// history endpoint.
+type Frame struct {
+	Seq   int64           `json:"seq"`
+	Ts    time.Time       `json:"ts"`
+	File  string          `json:"file,omitempty"`
+	Index int             `json:"index"`
+	Entry json.RawMessage `json:"entry"`
+}
+
+// Server serves the HTTP API described in the package doc.
+type Server struct {
+	bus  *bus.Bus
+	ring *ring
+
+	httpServer *http.Server
+	upgrader   websocket.Upgrader
+}
+
+// New creates a Server that will listen on addr, broadcasting events
+// published to b and retaining the last ringSize of them for late-joining
+// clients. ringSize <= 0 uses a sensible default.
+func New(addr string, b *bus.Bus, ringSize int) *Server {
+	if ringSize <= 0 {
+		ringSize = defaultRingSize
+	}
+
+	s := &Server{
+		bus:  b,
+		ring: newRing(ringSize),
+		upgrader: websocket.Upgrader{
+			// json-tail is a debugging/ops tool meant to be reached from
+			// arbitrary browsers and scripts, so cross-origin requests are
+			// accepted rather than locked to a single configured origin.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/entries", s.handleEntries)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/ws", s.handleWS)
+
+	s.httpServer = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// Ingest subscribes the server to b and records every event into its ring
+// buffer until ctx is done, broadcasting each resulting Frame to connected
+// SSE/WebSocket clients. Run this in its own goroutine, the same way a
+// sink.Sink is run. It is the only path that turns a bus.Event into a
+// Frame: handleEvents and handleWS merely relay the Frames it produces, so
+// an event is never recorded into the ring more than once regardless of
+// how many clients are connected.
+func (s *Server) Ingest(ctx context.Context, b *bus.Bus) {
+	ch := make(chan *bus.Event, 256)
+	b.Subscribe(ch)
+	defer b.Unsubscribe(ch)
+
+	for {
+		select {
+		case e := <-ch:
+			s.ring.add(e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ListenAndServe starts the HTTP server. It blocks until the server stops
+// or fails, mirroring net/http.Server.ListenAndServe.
+func (s *Server) ListenAndServe() error {
+	return s.httpServer.ListenAndServe()
+}
+
+// Shutdown gracefully stops the HTTP server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleEntries serves a page of ring-buffered history:
+// GET /entries?from=N&limit=M returns entries whose Index >= from, in
+// order, capped at limit (default and max 100).
+func (s *Server) handleEntries(w http.ResponseWriter, r *http.Request) {
+	from, err := queryInt(r, "from", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit", 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	frames := s.ring.page(from, limit)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(frames); err != nil {
+		log.Printf("server: encoding /entries response: %v", err)
+	}
+}
+
+// handleEvents serves new entries as Server-Sent Events. A reconnecting
+// client's Last-Event-ID header is honored: any ring-buffered events newer
+// than it are replayed before the stream switches to live updates.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Without a usable Last-Event-ID, replay the whole retained buffer, as
+	// handleWS does.
+	lastSeq := int64(-1)
+	if id, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		lastSeq = id
+	}
+
+	snapshot, ch, unsubscribe := s.ring.subscribe(lastSeq)
+	defer unsubscribe()
+
+	for _, f := range snapshot {
+		if !writeSSE(w, f) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case f := <-ch:
+			if !writeSSE(w, f) {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, f Frame) bool {
+	data, err := json.Marshal(f)
+	if err != nil {
+		log.Printf("server: marshaling SSE frame: %v", err)
+		return true
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", f.Seq, data)
+	return err == nil
+}
+
+// handleWS upgrades the connection to a WebSocket, sends the current ring
+// buffer as a burst of frames, then streams new frames as they arrive.
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("server: websocket upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	snapshot, ch, unsubscribe := s.ring.subscribe(-1)
+	defer unsubscribe()
+
+	for _, f := range snapshot {
+		if err := conn.WriteJSON(f); err != nil {
+			return
+		}
+	}
+
+	for f := range ch {
+		if err := conn.WriteJSON(f); err != nil {
+			return
+		}
+	}
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,251 @@
+// Package sink implements consumers that subscribe to the event bus and do
+// something with each entry: print it to the terminal, append it to a
+// rotating file, or forward it to the systemd journal.
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-systemd/journal"
+
+	"github.com/siliconchaos/json-tail/bus"
+	"github.com/siliconchaos/json-tail/spinner"
+)
+
+// Sink consumes events published to a bus until ctx is done.
+type Sink interface {
+	Run(ctx context.Context, b *bus.Bus)
+}
+
+// subscribe is the boilerplate every sink needs: register a buffered
+// channel with b and unsubscribe it once Run returns.
+func subscribe(b *bus.Bus) (ch chan *bus.Event, unsubscribe func()) {
+	ch = make(chan *bus.Event, 64)
+	b.Subscribe(ch)
+	return ch, func() { b.Unsubscribe(ch) }
+}
+
+// Terminal prints each entry to stdout, pausing the spinner while it does
+// so. This is the sink that reproduces json-tail's original behavior.
+type Terminal struct {
+	Spinner *spinner.Spinner
+	Render  func(json.RawMessage) (string, error)
+}
+
+// Run implements Sink.
+func (t *Terminal) Run(ctx context.Context, b *bus.Bus) {
+	ch, unsubscribe := subscribe(b)
+	defer unsubscribe()
+
+	for {
+		select {
+		case e := <-ch:
+			// Stop/Start bracket however many entries are already
+			// waiting, not just this one: a burst publishes one
+			// bus.Event per entry, and toggling the spinner per event
+			// would call Start faster than its goroutine can notice the
+			// matching Stop, leaking goroutines and racing on stdout.
+			t.Spinner.Stop()
+			t.print(e)
+			draining := true
+			for draining {
+				select {
+				case e := <-ch:
+					t.print(e)
+				default:
+					draining = false
+				}
+			}
+			t.Spinner.SetState("Watching")
+			t.Spinner.Start()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *Terminal) print(e *bus.Event) {
+	line, err := t.Render(e.Entry)
+	if err != nil {
+		log.Printf("terminal sink: rendering entry %d from %s: %v", e.Index, e.File, err)
+		return
+	}
+	fmt.Printf("[%s] %s\n", time.Now().Format(time.RFC3339), line)
+}
+
+// defaultFileMaxBytes is the rotation threshold used when a File sink
+// doesn't specify one.
+const defaultFileMaxBytes = 10 * 1024 * 1024
+
+// File appends each entry as a JSON line to Path, rotating it to
+// Path+".1" once it grows past MaxBytes.
+type File struct {
+	Path     string
+	MaxBytes int64 // 0 uses defaultFileMaxBytes; negative disables rotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Run implements Sink.
+func (f *File) Run(ctx context.Context, b *bus.Bus) {
+	ch, unsubscribe := subscribe(b)
+	defer unsubscribe()
+	defer f.close()
+
+	for {
+		select {
+		case e := <-ch:
+			if err := f.write(e.Entry); err != nil {
+				log.Printf("file sink: writing entry %d from %s: %v", e.Index, e.File, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *File) write(entry json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	maxBytes := f.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultFileMaxBytes
+	}
+	if maxBytes > 0 && f.size >= maxBytes {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	line := append(append([]byte(nil), entry...), '\n')
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	return err
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", f.Path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stating %s: %w", f.Path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *File) rotate() error {
+	f.file.Close()
+	f.file = nil
+	if err := os.Rename(f.Path, f.Path+".1"); err != nil {
+		return fmt.Errorf("rotating %s: %w", f.Path, err)
+	}
+	return f.open()
+}
+
+func (f *File) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// Journald forwards each entry to the systemd journal with structured
+// fields (JSON_TAIL_FILE, JSON_TAIL_INDEX, plus the entry's own top-level
+// keys flattened into journald-safe field names), so operators can filter
+// with e.g. `journalctl JSON_TAIL_FILE=/var/log/app.json`.
+type Journald struct{}
+
+// Run implements Sink.
+func (j *Journald) Run(ctx context.Context, b *bus.Bus) {
+	ch, unsubscribe := subscribe(b)
+	defer unsubscribe()
+
+	for {
+		select {
+		case e := <-ch:
+			if err := j.send(e); err != nil {
+				log.Printf("journald sink: entry %d from %s: %v", e.Index, e.File, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (j *Journald) send(e *bus.Event) error {
+	fields := map[string]string{
+		"JSON_TAIL_FILE":  e.File,
+		"JSON_TAIL_INDEX": strconv.Itoa(e.Index),
+	}
+	for k, v := range flatten(e.Entry) {
+		fields[k] = v
+	}
+	return journal.Send(string(e.Entry), journal.PriInfo, fields)
+}
+
+// flatten extracts the top-level keys of a JSON object entry into
+// journald-safe field names. Non-object entries yield no extra fields.
+func flatten(raw json.RawMessage) map[string]string {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string, len(obj))
+	for k, v := range obj {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			fields[journalField(k)] = s
+			continue
+		}
+		fields[journalField(k)] = string(v)
+	}
+	return fields
+}
+
+// journalField upper-cases key and replaces each character that isn't
+// valid in a journald field name with an underscore. Leading underscores
+// are then stripped, since journald reserves them for its own trusted
+// fields (e.g. "_id" becomes "ID", not "_ID"), and a name that is left
+// empty or starts with a digit, which journald also rejects, gets an "F"
+// prepended.
+func journalField(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+package watch
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsnotifyWatcher watches the target file's parent directory rather than
+// the file itself: watching the file directly loses the notification
+// after a rename or remove, since the inotify watch is tied to the old
+// inode. Watching the directory lets us see the file reappear under the
+// same name after rotation.
+type fsnotifyWatcher struct {
+	watcher *fsnotify.Watcher
+	events  chan Event
+	errors  chan error
+	done    chan struct{}
+}
+
+// NewFSNotify creates a Watcher for filename backed by OS file-change
+// notifications.
+func NewFSNotify(filename string) (Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating fsnotify watcher: %w", err)
+	}
+
+	dir := filepath.Dir(filename)
+	if err := fw.Add(dir); err != nil {
+		fw.Close()
+		return nil, fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	w := &fsnotifyWatcher{
+		watcher: fw,
+		events:  make(chan Event, 16),
+		errors:  make(chan error, 16),
+		done:    make(chan struct{}),
+	}
+	go w.run(filepath.Base(filename))
+	return w, nil
+}
+
+func (w *fsnotifyWatcher) run(target string) {
+	defer close(w.events)
+	defer close(w.errors)
+
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Base(ev.Name) != target {
+				continue
+			}
+			switch {
+			case ev.Op&fsnotify.Write == fsnotify.Write:
+				w.emit(EventWrite)
+			case ev.Op&(fsnotify.Rename|fsnotify.Remove) != 0:
+				w.emit(EventRotate)
+			case ev.Op&fsnotify.Create == fsnotify.Create:
+				w.emit(EventCreate)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errors <- err:
+			default:
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *fsnotifyWatcher) emit(e Event) {
+	select {
+	case w.events <- e:
+	default:
+	}
+}
+
+func (w *fsnotifyWatcher) Events() <-chan Event { return w.events }
+func (w *fsnotifyWatcher) Errors() <-chan error { return w.errors }
+
+func (w *fsnotifyWatcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
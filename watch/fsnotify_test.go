@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSNotifyWatcherDetectsWriteRotateAndCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing initial file: %v", err)
+	}
+
+	w, err := NewFSNotify(path)
+	if err != nil {
+		t.Skipf("fsnotify unavailable in this environment: %v", err)
+	}
+	defer w.Close()
+
+	// Append: should be reported as a write.
+	appendTo(t, path, `{"a":1}`+"\n")
+	waitForEvent(t, w.Events(), EventWrite)
+
+	// Rotate: rename the file away, then recreate it. fsnotifyWatcher
+	// watches the parent directory precisely so it survives this.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("renaming: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventRotate)
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("recreating file: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventCreate)
+
+	// The reopened file should still be tracked for further writes.
+	appendTo(t, path, `{"a":2}`+"\n")
+	waitForEvent(t, w.Events(), EventWrite)
+}
+
+func TestFSNotifyWatcherDetectsRemoval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing initial file: %v", err)
+	}
+
+	w, err := NewFSNotify(path)
+	if err != nil {
+		t.Skipf("fsnotify unavailable in this environment: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("removing file: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventRotate)
+}
+
+func TestFSNotifyWatcherIgnoresOtherFilesInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing initial file: %v", err)
+	}
+
+	w, err := NewFSNotify(path)
+	if err != nil {
+		t.Skipf("fsnotify unavailable in this environment: %v", err)
+	}
+	defer w.Close()
+
+	// A write to an unrelated sibling file must not surface as an event;
+	// only a subsequent write to the watched file should.
+	sibling := filepath.Join(dir, "other.json")
+	if err := os.WriteFile(sibling, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing sibling file: %v", err)
+	}
+	appendTo(t, path, `{"a":1}`+"\n")
+	waitForEvent(t, w.Events(), EventWrite)
+}
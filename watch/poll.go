@@ -0,0 +1,88 @@
+package watch
+
+import (
+	"os"
+	"time"
+)
+
+// pollWatcher detects changes by periodically stat'ing the file and
+// comparing against its previous size and existence. It's the fallback
+// for platforms or sandboxes where fsnotify isn't available, and is also
+// useful for tests since its timing is deterministic.
+type pollWatcher struct {
+	filename string
+	interval time.Duration
+	events   chan Event
+	errors   chan error
+	done     chan struct{}
+}
+
+// NewPoll creates a Watcher for filename that checks for changes every
+// interval. The file's current size is recorded immediately, before the
+// polling goroutine starts, so a change made right after NewPoll returns
+// is never missed.
+func NewPoll(filename string, interval time.Duration) Watcher {
+	w := &pollWatcher{
+		filename: filename,
+		interval: interval,
+		events:   make(chan Event, 16),
+		errors:   make(chan error, 16),
+		done:     make(chan struct{}),
+	}
+	lastSize, lastExists := w.stat()
+	go w.run(lastSize, lastExists)
+	return w
+}
+
+func (w *pollWatcher) run(lastSize int64, lastExists bool) {
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			size, exists := w.stat()
+			switch {
+			case exists && !lastExists:
+				w.emit(EventCreate)
+			case !exists && lastExists:
+				w.emit(EventRotate)
+			case exists && lastExists && size < lastSize:
+				// The file shrank without disappearing: most likely it
+				// was truncated in place or replaced between our two
+				// stat calls, so treat it the same as a rotation.
+				w.emit(EventRotate)
+			case exists && lastExists && size > lastSize:
+				w.emit(EventWrite)
+			}
+			lastSize, lastExists = size, exists
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *pollWatcher) stat() (size int64, exists bool) {
+	info, err := os.Stat(w.filename)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+func (w *pollWatcher) emit(e Event) {
+	select {
+	case w.events <- e:
+	default:
+	}
+}
+
+func (w *pollWatcher) Events() <-chan Event { return w.events }
+func (w *pollWatcher) Errors() <-chan error { return w.errors }
+
+func (w *pollWatcher) Close() error {
+	close(w.done)
+	return nil
+}
@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForEvent reads from events until it sees want or the timeout
+// expires, failing the test in the latter case. Intervening events (e.g.
+// extra writes picked up between polls) are discarded.
+func waitForEvent(t *testing.T, events <-chan Event, want Event) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case got := <-events:
+			if got == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+	}
+}
+
+func TestPollWatcherDetectsWriteRotateAndCreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing initial file: %v", err)
+	}
+
+	w := NewPoll(path, 10*time.Millisecond)
+	defer w.Close()
+
+	// Append: should be reported as a write.
+	appendTo(t, path, `{"a":1}`+"\n")
+	waitForEvent(t, w.Events(), EventWrite)
+
+	// Rotate: rename the file away, then recreate it smaller than before.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("renaming: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventRotate)
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("recreating file: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventCreate)
+
+	// The reopened file should still be tracked for further writes.
+	appendTo(t, path, `{"a":2}`+"\n")
+	waitForEvent(t, w.Events(), EventWrite)
+}
+
+func TestPollWatcherDetectsRemoval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("writing initial file: %v", err)
+	}
+
+	w := NewPoll(path, 10*time.Millisecond)
+	defer w.Close()
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("removing file: %v", err)
+	}
+	waitForEvent(t, w.Events(), EventRotate)
+}
+
+func appendTo(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("opening %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatalf("writing to %s: %v", path, err)
+	}
+}
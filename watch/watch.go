@@ -0,0 +1,94 @@
+// Package watch notifies callers when a watched file is written to,
+// rotated (removed or renamed out from under the watch), or recreated.
+// An fsnotify-based implementation is preferred; a polling implementation
+// is available as a fallback for platforms or sandboxes where fsnotify
+// can't be started.
+package watch
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Event describes a single change observed on the watched file.
+type Event int
+
+const (
+	// EventWrite means new bytes were appended (or otherwise written) to
+	// the file.
+	EventWrite Event = iota
+	// EventRotate means the file was removed or renamed away; the caller
+	// should reopen the original path from the start.
+	EventRotate
+	// EventCreate means a file that didn't previously exist at the
+	// watched path has appeared.
+	EventCreate
+)
+
+// String implements fmt.Stringer for use in log messages.
+func (e Event) String() string {
+	switch e {
+	case EventWrite:
+		return "write"
+	case EventRotate:
+		return "rotate"
+	case EventCreate:
+		return "create"
+	default:
+		return "unknown"
+	}
+}
+
+// Watcher reports changes to a single watched file.
+type Watcher interface {
+	// Events delivers change notifications. It is closed when the
+	// Watcher stops.
+	Events() <-chan Event
+	// Errors delivers non-fatal errors encountered while watching.
+	Errors() <-chan error
+	// Close stops the Watcher and releases any underlying resources.
+	Close() error
+}
+
+// Mode selects which Watcher implementation New creates.
+type Mode string
+
+const (
+	// ModeAuto prefers fsnotify, falling back to polling if fsnotify
+	// can't be started.
+	ModeAuto Mode = ""
+	// ModeFSNotify uses OS file-change notifications.
+	ModeFSNotify Mode = "fsnotify"
+	// ModePoll periodically stats the file to detect changes.
+	ModePoll Mode = "poll"
+)
+
+// ParseMode validates and normalizes a --watch flag value.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeAuto, ModeFSNotify, ModePoll:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown watch mode %q (want %q or %q)", s, ModeFSNotify, ModePoll)
+	}
+}
+
+// New creates a Watcher for filename using mode. pollInterval is used
+// directly by ModePoll, and as the fallback interval if ModeAuto has to
+// fall back from fsnotify to polling.
+func New(filename string, mode Mode, pollInterval time.Duration) (Watcher, error) {
+	switch mode {
+	case ModePoll:
+		return NewPoll(filename, pollInterval), nil
+	case ModeFSNotify:
+		return NewFSNotify(filename)
+	default:
+		w, err := NewFSNotify(filename)
+		if err == nil {
+			return w, nil
+		}
+		log.Printf("watch: fsnotify unavailable (%v), falling back to polling", err)
+		return NewPoll(filename, pollInterval), nil
+	}
+}